@@ -0,0 +1,171 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package criteria_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/facebook/ent/dialect"
+	"github.com/facebook/ent/dialect/sql"
+	"github.com/facebook/ent/dialect/sql/sqljson/criteria"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	c := criteria.All(
+		criteria.Eq("meta.age", 30),
+		criteria.Any(
+			criteria.Contains("meta.tags", "go"),
+		),
+	)
+	data, err := json.Marshal(c)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"all":[{"eq":{"field":"meta.age","value":30}},{"any":[{"contains":{"field":"meta.tags","value":"go"}}]}]}`, string(data))
+
+	var got criteria.Criteria
+	require.NoError(t, json.Unmarshal(data, &got))
+	data2, err := json.Marshal(&got)
+	require.NoError(t, err)
+	require.JSONEq(t, string(data), string(data2))
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	var c criteria.Criteria
+	require.Error(t, json.Unmarshal([]byte(`{"eq":{"field":"meta.age","value":30},"neq":{"field":"meta.age","value":31}}`), &c))
+	require.Error(t, json.Unmarshal([]byte(`{"xor":{"field":"meta.age","value":30}}`), &c))
+}
+
+func TestToPredicate(t *testing.T) {
+	c := criteria.All(
+		criteria.Eq("meta.age", 30),
+		criteria.HasKey("meta.nickname"),
+	)
+	p, err := c.ToPredicate(dialect.Postgres)
+	require.NoError(t, err)
+	query, args := sql.Dialect(dialect.Postgres).
+		Select("*").
+		From(sql.Table("users")).
+		Where(p).
+		Query()
+	require.Equal(t, `SELECT * FROM "users" WHERE "meta"->'age' = $1 AND "meta"->'nickname' IS NOT NULL`, query)
+	require.Equal(t, []interface{}{30}, args)
+}
+
+func TestToPredicateInvalidField(t *testing.T) {
+	_, err := criteria.Eq("age", 30).ToPredicate(dialect.MySQL)
+	require.Error(t, err)
+}
+
+func TestToPredicateInvalidPath(t *testing.T) {
+	_, err := criteria.Eq("meta.a[", 30).ToPredicate(dialect.MySQL)
+	require.Error(t, err)
+}
+
+// TestToPredicateColumnInjection exercises field carrying an invalid, and
+// potentially injected, column segment (field is untrusted; see the package
+// doc). ToPredicate must reject it rather than splicing it unescaped into
+// the query text via sql.Builder.Ident.
+func TestToPredicateColumnInjection(t *testing.T) {
+	_, err := criteria.Eq("a`) OR 1=1; --.meta.age", 30).ToPredicate(dialect.MySQL)
+	require.Error(t, err)
+}
+
+func TestToPredicateKinds(t *testing.T) {
+	tests := []struct {
+		name      string
+		c         *criteria.Criteria
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "not",
+			c:         criteria.Not(criteria.Eq("meta.age", 30)),
+			wantQuery: "SELECT * FROM `users` WHERE NOT (JSON_EXTRACT(`meta`, \"$.age\") = ?)",
+			wantArgs:  []interface{}{30},
+		},
+		{
+			name:      "gt",
+			c:         criteria.Gt("meta.age", 30),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_EXTRACT(`meta`, \"$.age\") > ?",
+			wantArgs:  []interface{}{30},
+		},
+		{
+			name:      "gte",
+			c:         criteria.Gte("meta.age", 30),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_EXTRACT(`meta`, \"$.age\") >= ?",
+			wantArgs:  []interface{}{30},
+		},
+		{
+			name:      "lt",
+			c:         criteria.Lt("meta.age", 30),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_EXTRACT(`meta`, \"$.age\") < ?",
+			wantArgs:  []interface{}{30},
+		},
+		{
+			name:      "lte",
+			c:         criteria.Lte("meta.age", 30),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_EXTRACT(`meta`, \"$.age\") <= ?",
+			wantArgs:  []interface{}{30},
+		},
+		{
+			name:      "in",
+			c:         criteria.In("meta.age", 30, 31),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_EXTRACT(`meta`, \"$.age\") = ? OR JSON_EXTRACT(`meta`, \"$.age\") = ?",
+			wantArgs:  []interface{}{30, 31},
+		},
+		{
+			name:      "between",
+			c:         criteria.Between("meta.age", 30, 40),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_EXTRACT(`meta`, \"$.age\") >= ? AND JSON_EXTRACT(`meta`, \"$.age\") <= ?",
+			wantArgs:  []interface{}{30, 40},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.c.ToPredicate(dialect.MySQL)
+			require.NoError(t, err)
+			query, args := sql.Select("*").
+				From(sql.Table("users")).
+				Where(p).
+				Query()
+			require.Equal(t, tt.wantQuery, query)
+			require.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestToPredicateSQLite(t *testing.T) {
+	c := criteria.Eq("meta.age", 30)
+	p, err := c.ToPredicate(dialect.SQLite)
+	require.NoError(t, err)
+	query, args := sql.Dialect(dialect.SQLite).
+		Select("*").
+		From(sql.Table("users")).
+		Where(p).
+		Query()
+	require.Equal(t, "SELECT * FROM `users` WHERE json_extract(`meta`, '$.age') = ?", query)
+	require.Equal(t, []interface{}{30}, args)
+}
+
+// TestToPredicateFieldInjection exercises the realistic attack surface: field
+// comes straight from a saved (externally-controlled) JSON document, and can
+// itself embed a gjson-style filter segment. The compared value inside that
+// filter must end up bound as a query argument, never spliced into the query
+// text, even though it arrived via field rather than value.
+func TestToPredicateFieldInjection(t *testing.T) {
+	var c criteria.Criteria
+	require.NoError(t, json.Unmarshal(
+		[]byte(`{"eq":{"field":"meta.friends.#(last==\"x' OR '1'='1\").first","value":"Luke"}}`), &c,
+	))
+	p, err := c.ToPredicate(dialect.MySQL)
+	require.NoError(t, err)
+	query, args := sql.Select("*").
+		From(sql.Table("users")).
+		Where(p).
+		Query()
+	require.NotContains(t, query, "OR '1'='1'")
+	require.Equal(t, []interface{}{"x' OR '1'='1", "Luke"}, args)
+}
@@ -0,0 +1,187 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package sqljson
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/facebook/ent/dialect/sql"
+)
+
+// filterOps lists the operators recognized inside a "#(path op value)"
+// filter segment, longest first so that, e.g., "<=" is matched before "<".
+var filterOps = []string{"==", "!=", "<=", ">=", "<", ">", "%"}
+
+// filterSubPathPattern restricts a filter's SubPath (e.g. "last" in
+// `#(last=="Murphy")`) to a plain dotted identifier. Unlike the compared
+// value, SubPath is spliced directly into generated SQL text (a MySQL
+// REPLACE literal, a Postgres jsonpath string), so it can't be bound as a
+// query argument the way the value is; rejecting anything outside this
+// charset keeps it from ever breaking out of that text.
+var filterSubPathPattern = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+
+// parseFilter parses the body of a "#(...)"/"#[...]" segment starting right
+// after the opening paren/bracket, up to the matching closeCh. It returns
+// the parsed Element and the index right after the closing character.
+func parseFilter(s string, start int, closeCh byte) (Element, int, error) {
+	j, inQuote := start, false
+	for j < len(s) {
+		switch {
+		case inQuote && s[j] == '\\':
+			j += 2
+			continue
+		case s[j] == '"':
+			inQuote = !inQuote
+		case s[j] == closeCh && !inQuote:
+			el, err := parseFilterBody(s[start:j])
+			return el, j + 1, err
+		}
+		j++
+	}
+	return Element{}, 0, fmt.Errorf("sqljson: unterminated filter in path %q", s)
+}
+
+// parseFilterBody splits a filter body of the form "path op value" (e.g.
+// `last=="Murphy"` or `age>=30`) into an Element.
+func parseFilterBody(body string) (Element, error) {
+	for _, op := range filterOps {
+		idx := strings.Index(body, op)
+		if idx < 0 {
+			continue
+		}
+		sub, val := strings.TrimSpace(body[:idx]), strings.TrimSpace(body[idx+len(op):])
+		if sub == "" || val == "" {
+			return Element{}, fmt.Errorf("sqljson: invalid filter %q", body)
+		}
+		if !filterSubPathPattern.MatchString(sub) {
+			return Element{}, fmt.Errorf("sqljson: invalid filter sub-path %q", sub)
+		}
+		el := Element{Kind: KindFilter, SubPath: sub, Op: op}
+		if val[0] == '"' {
+			if len(val) < 2 || val[len(val)-1] != '"' {
+				return Element{}, fmt.Errorf("sqljson: unterminated quote in filter value %q", val)
+			}
+			el.Filter = val[1 : len(val)-1]
+			el.Quoted = true
+			el.vesc = strings.Contains(el.Filter, `\"`)
+		} else {
+			el.Filter = val
+		}
+		return el, nil
+	}
+	return Element{}, fmt.Errorf("sqljson: unknown filter operator in %q", body)
+}
+
+// writeFilterExtractMySQL renders a path containing a gjson-style filter
+// element (e.g. "friends.#(last==\"Murphy\").first") by locating the
+// matching array element with JSON_SEARCH and then reading suffix off of
+// it with JSON_EXTRACT. It mirrors the $[*] wildcard gjson uses to scan
+// every element of the array addressed by prefix.
+//
+// The compared value is always bound as a query argument (never spliced
+// into the query text), since it comes from caller-supplied path strings
+// that may themselves be external data (see criteria.Criteria.field).
+//
+// Note this only supports the equality/LIKE-style comparisons that
+// JSON_SEARCH itself understands (== and %); other operators are accepted
+// by ParsePath but require a JSON_TABLE-based rewrite that isn't built yet.
+func writeFilterExtractMySQL(b *sql.Builder, column string, prefix []Element, f Element, suffix []Element) {
+	mode := "one"
+	if f.All {
+		mode = "all"
+	}
+	matchPath := dotPath(append(append([]Element{}, prefix...), Element{Kind: KindIndex, Value: "[*]"}, Element{Kind: KindKey, Value: f.SubPath}))
+	b.WriteString("JSON_EXTRACT(")
+	b.Ident(column)
+	b.WriteString(", REPLACE(JSON_UNQUOTE(JSON_SEARCH(")
+	b.Ident(column)
+	b.WriteString(", '" + mode + "', ")
+	b.Arg(filterValue(f))
+	b.WriteString(", NULL, ")
+	b.WriteString(matchPath)
+	b.WriteString(")), '." + f.SubPath + "', '")
+	b.WriteString(dotSuffix(suffix))
+	b.WriteString("'))")
+}
+
+// filterValue returns the compared value with any \" escapes (introduced
+// only so parseFilter could find the closing quote) resolved back to ",
+// ready to be bound as a query argument.
+func filterValue(f Element) string {
+	if f.vesc {
+		return strings.ReplaceAll(f.Filter, `\"`, `"`)
+	}
+	return f.Filter
+}
+
+// dotSuffix renders the remainder of a path (after the filter element) as
+// the MySQL dotpath fragment REPLACE should splice in, without the leading "$".
+func dotSuffix(path []Element) string {
+	full := dotPath(path)
+	// dotPath wraps the result in quotes and a leading "$"; strip both so the
+	// caller gets a bare ".field[1]..." fragment to append after the match.
+	return strings.TrimPrefix(strings.Trim(full, `"`), "$")
+}
+
+// postgresFilterPath renders a path containing a gjson-style filter element
+// as a Postgres SQL/JSON path, e.g. "$.friends[*] ? (@.last == $f).first".
+// The compared value itself is never embedded in the path literal: it's
+// passed alongside as the "f" entry of a jsonpath vars object bound as a
+// query argument (see writePathPostgres), so caller-supplied filter values
+// can't break out of the path expression.
+func postgresFilterPath(prefix []Element, f Element, suffix []Element) string {
+	b := &strings.Builder{}
+	b.WriteString("$")
+	writeJSONPathSegments(b, prefix)
+	b.WriteString("[*] ? (@.")
+	b.WriteString(f.SubPath)
+	b.WriteString(" ")
+	b.WriteString(postgresFilterCond(f))
+	b.WriteString(")")
+	writeJSONPathSegments(b, suffix)
+	return b.String()
+}
+
+func writeJSONPathSegments(b *strings.Builder, path []Element) {
+	for _, e := range path {
+		if e.Kind == KindIndex {
+			b.WriteString(e.Value)
+		} else {
+			b.WriteString(".")
+			b.WriteString(unquoteSegment(e.Value))
+		}
+	}
+}
+
+// postgresFilterCond renders the "op $f" half of a jsonpath filter
+// condition, referencing the "f" variable bound in the query's vars object
+// rather than embedding the value in the path text. % (LIKE-style)
+// comparisons compile to like_regex.
+func postgresFilterCond(f Element) string {
+	if f.Op == "%" {
+		return "like_regex $f"
+	}
+	return f.Op + " $f"
+}
+
+// postgresFilterVar converts the compared value to the Go type that, once
+// bound as a query argument inside jsonb_build_object, makes Postgres store
+// it as the matching jsonb scalar (number/boolean/string) for comparison.
+func postgresFilterVar(f Element) interface{} {
+	v := filterValue(f)
+	if f.Quoted || f.Op == "%" {
+		return v
+	}
+	if n, err := strconv.ParseFloat(v, 64); err == nil {
+		return n
+	}
+	if bl, err := strconv.ParseBool(v); err == nil {
+		return bl
+	}
+	return v
+}
@@ -0,0 +1,743 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+// Package sqljson provides a generic JSON builder for predicates and
+// comparators for different dialects.
+package sqljson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/facebook/ent/dialect"
+	"github.com/facebook/ent/dialect/sql"
+)
+
+type (
+	// PathOptions holds the configuration for reading/comparing a JSON value
+	// that sits behind a path inside a JSON column. Paths is not set by any
+	// PathOption nor read by any predicate of this package; HasAnyPath and
+	// HasAllPath take their paths as plain arguments instead.
+	PathOptions struct {
+		Path    []Element
+		Paths   [][]string
+		Cast    string
+		Unquote bool
+	}
+
+	// PathOption allows configuring the PathOptions using functional options.
+	PathOption func(*PathOptions)
+
+	// Kind identifies the type of a single path Element.
+	Kind uint8
+
+	// Element is a single segment of a parsed path. Most elements are plain
+	// object keys or array indexes; a KindFilter element additionally
+	// describes a gjson-style array filter (e.g. "#(age>=30)"), see ParsePath.
+	Element struct {
+		Kind Kind
+		// Value holds the raw segment text for KindKey (quotes included for
+		// quoted keys) and KindIndex (brackets included, e.g. "[1]").
+		Value string
+		// SubPath, Op and Filter describe a KindFilter element: SubPath is
+		// the field tested on each array element (e.g. "last"), Op is one
+		// of ==, !=, <, <=, >, >= or % (LIKE-style match), and Filter is the
+		// value it's compared against.
+		SubPath string
+		Op      string
+		Filter  string
+		// Quoted reports whether Filter came from a quoted string literal
+		// (as opposed to a bare number/boolean token).
+		Quoted bool
+		// All reports whether the filter used the "#(...)#" form ("match
+		// every element") rather than "#(...)" ("match the first element").
+		All bool
+		// vesc reports that Filter contains \" escapes that still need to
+		// be unescaped by the caller once it decides how to render them.
+		vesc bool
+	}
+)
+
+const (
+	// KindKey is a plain (optionally quoted) object key.
+	KindKey Kind = iota
+	// KindIndex is an array index (e.g. "[1]").
+	KindIndex
+	// KindFilter is a gjson-style array filter (e.g. "#(last==\"Murphy\")").
+	KindFilter
+)
+
+// Path sets the path to the JSON value as a list of keys and/or indexes
+// (e.g. Path("a", "b", "[1]", "c")). Use DotPath to also embed filters.
+func Path(path ...string) PathOption {
+	return func(opts *PathOptions) {
+		opts.Path = elements(path)
+	}
+}
+
+// DotPath sets the path to the JSON value using the "dotpath" format
+// (e.g. "a.b[1].c" or "friends.#(last==\"Murphy\").first"). See ParsePath
+// for the accepted syntax.
+func DotPath(dotpath string) PathOption {
+	return func(opts *PathOptions) {
+		path, err := ParsePath(dotpath)
+		if err != nil {
+			path = []Element{{Kind: KindKey, Value: dotpath}}
+		}
+		opts.Path = path
+	}
+}
+
+// Unquote indicates that the result value should be unquoted before
+// it is compared or returned.
+func Unquote(unquote bool) PathOption {
+	return func(opts *PathOptions) {
+		opts.Unquote = unquote
+	}
+}
+
+// Cast indicates that the result value should be cast to the given type
+// before it is compared or returned. The type name is dialect specific
+// (e.g. "int", "unsigned", "char").
+func Cast(typ string) PathOption {
+	return func(opts *PathOptions) {
+		opts.Cast = typ
+	}
+}
+
+func (o *PathOptions) path() []Element {
+	return o.Path
+}
+
+// ParsePath parses the "dotpath" format (the format used by gjson and by
+// MySQL's "$.a.b" path expressions, minus the leading "$") into its list of
+// path elements. Array indexes are returned as KindIndex ("[1]") and quoted
+// segments (e.g. `"a.b"`) are returned verbatim, quotes included, so that
+// callers can tell a literal dot from a path separator. A segment of the
+// form "#(path op value)" or "#(path op value)#" is parsed as a KindFilter
+// element describing a gjson-style array filter (e.g.
+// `friends.#(last=="Murphy").first`); the trailing "#" selects every
+// matching element instead of just the first one.
+func ParsePath(dotpath string) ([]Element, error) {
+	var (
+		i    int
+		path []Element
+	)
+	for i < len(dotpath) {
+		switch c := dotpath[i]; {
+		case c == '.':
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(dotpath) && dotpath[j] != '"' {
+				j++
+			}
+			if j == len(dotpath) {
+				return nil, fmt.Errorf("sqljson: unterminated quote in path %q", dotpath)
+			}
+			path = append(path, Element{Kind: KindKey, Value: dotpath[i : j+1]})
+			i = j + 1
+		case c == '[':
+			j := i + 1
+			for j < len(dotpath) && dotpath[j] != ']' {
+				j++
+			}
+			if j == len(dotpath) {
+				return nil, fmt.Errorf("sqljson: unterminated bracket in path %q", dotpath)
+			}
+			if _, err := strconv.Atoi(dotpath[i+1 : j]); err != nil {
+				return nil, fmt.Errorf("sqljson: invalid index %q in path %q", dotpath[i+1:j], dotpath)
+			}
+			path = append(path, Element{Kind: KindIndex, Value: dotpath[i : j+1]})
+			i = j + 1
+		case c == '#' && i+1 < len(dotpath) && (dotpath[i+1] == '(' || dotpath[i+1] == '['):
+			closeCh := byte(')')
+			if dotpath[i+1] == '[' {
+				closeCh = ']'
+			}
+			el, next, err := parseFilter(dotpath, i+2, closeCh)
+			if err != nil {
+				return nil, err
+			}
+			i = next
+			if i < len(dotpath) && dotpath[i] == '#' {
+				el.All = true
+				i++
+			}
+			path = append(path, el)
+		default:
+			j := i
+			for j < len(dotpath) && dotpath[j] != '.' && dotpath[j] != '[' {
+				j++
+			}
+			path = append(path, Element{Kind: KindKey, Value: dotpath[i:j]})
+			i = j
+		}
+	}
+	return path, nil
+}
+
+// isIndex reports whether the given path segment is an array index (e.g. "[1]").
+func isIndex(p string) bool {
+	return len(p) > 1 && p[0] == '[' && p[len(p)-1] == ']'
+}
+
+// unquoteSegment strips the surrounding quotes of a quoted path segment,
+// leaving plain segments and indexes untouched.
+func unquoteSegment(p string) string {
+	if len(p) > 1 && p[0] == '"' && p[len(p)-1] == '"' {
+		return p[1 : len(p)-1]
+	}
+	return p
+}
+
+// elements converts a list of plain path keys/indexes (the Path option's
+// convenience API) into their Element form.
+func elements(path []string) []Element {
+	els := make([]Element, len(path))
+	for i, p := range path {
+		k := KindKey
+		if isIndex(p) {
+			k = KindIndex
+		}
+		els[i] = Element{Kind: k, Value: p}
+	}
+	return els
+}
+
+// filterIndex returns the index of the first KindFilter element in path, or
+// -1 if path has no filter.
+func filterIndex(path []Element) int {
+	for i, e := range path {
+		if e.Kind == KindFilter {
+			return i
+		}
+	}
+	return -1
+}
+
+// dotPath renders path as a MySQL "$.a.b[1].c" literal, quotes included
+// (see dotPathSQLite for the SQLite form). path must not contain a KindFilter element; see
+// writeFilterExtractMySQL for those.
+func dotPath(path []Element) string {
+	b := &strings.Builder{}
+	b.WriteString("$")
+	for _, e := range path {
+		if e.Kind == KindIndex {
+			b.WriteString(e.Value)
+		} else {
+			b.WriteString(".")
+			b.WriteString(e.Value)
+		}
+	}
+	return `"` + b.String() + `"`
+}
+
+// writePath writes the dialect specific expression used to read the JSON
+// value located at opts.Path behind column, applying Cast/Unquote as
+// configured.
+func writePath(b *sql.Builder, column string, opts *PathOptions) {
+	switch b.Dialect() {
+	case dialect.Postgres:
+		writePathPostgres(b, column, opts.Path, opts.Cast, opts.Unquote)
+	case dialect.SQLite:
+		writePathSQLite(b, column, opts.Path, opts.Cast)
+	default: // MySQL.
+		writePathMySQL(b, column, opts.Path, opts.Cast, opts.Unquote)
+	}
+}
+
+func writePathMySQL(b *sql.Builder, column string, path []Element, cast string, unquote bool) {
+	if cast != "" {
+		b.WriteString("CAST(")
+	}
+	if unquote {
+		b.WriteString("JSON_UNQUOTE(")
+	}
+	if idx := filterIndex(path); idx != -1 {
+		writeFilterExtractMySQL(b, column, path[:idx], path[idx], path[idx+1:])
+	} else {
+		b.WriteString("JSON_EXTRACT(")
+		b.Ident(column)
+		b.WriteString(", ")
+		b.WriteString(dotPath(path))
+		b.WriteString(")")
+	}
+	if unquote {
+		b.WriteString(")")
+	}
+	if cast != "" {
+		b.WriteString(" AS ")
+		b.WriteString(cast)
+		b.WriteString(")")
+	}
+}
+
+// sqliteRejectFilter reports whether path contains a gjson-style filter
+// element and, if so, records an error on b. SQLite rendering has no
+// equivalent of writeFilterExtractMySQL/postgresFilterPath yet, so callers
+// must check this before rendering path rather than silently producing a
+// garbled "$.friends..first"-style expression (a KindFilter element's Value
+// field is empty).
+func sqliteRejectFilter(b *sql.Builder, path []Element) bool {
+	if filterIndex(path) == -1 {
+		return false
+	}
+	b.AddError(fmt.Errorf("sqljson: gjson-style path filters are not supported on SQLite"))
+	return true
+}
+
+// writePathSQLite renders the SQLite json_extract(col, '$.a.b[1]') form.
+// Unquote is intentionally not a parameter here: json_extract already
+// returns an unquoted scalar for any non-object/array leaf value, so
+// sqljson.Unquote(true) is a no-op on this dialect.
+func writePathSQLite(b *sql.Builder, column string, path []Element, cast string) {
+	if sqliteRejectFilter(b, path) {
+		return
+	}
+	if cast != "" {
+		b.WriteString("CAST(")
+	}
+	b.WriteString("json_extract(")
+	b.Ident(column)
+	b.WriteString(", ")
+	b.WriteString(dotPathSQLite(path))
+	b.WriteString(")")
+	if cast != "" {
+		b.WriteString(" AS ")
+		b.WriteString(sqliteType(cast))
+		b.WriteString(")")
+	}
+}
+
+// dotPathSQLite renders path as a SQLite "$.a.b[1].c" literal. SQLite's
+// json_extract uses the same dotpath grammar as MySQL's JSON_EXTRACT, but
+// takes it as a single-quoted string literal rather than a double-quoted one.
+func dotPathSQLite(path []Element) string {
+	b := &strings.Builder{}
+	b.WriteString("$")
+	for _, e := range path {
+		if e.Kind == KindIndex {
+			b.WriteString(e.Value)
+		} else {
+			b.WriteString(".")
+			b.WriteString(e.Value)
+		}
+	}
+	return "'" + b.String() + "'"
+}
+
+// sqliteTypes translates MySQL-style cast type names to the closest SQLite
+// type affinity, since SQLite has no "int"/"unsigned"/"char" types of its own.
+var sqliteTypes = map[string]string{
+	"int":      "INTEGER",
+	"integer":  "INTEGER",
+	"unsigned": "INTEGER",
+	"bigint":   "INTEGER",
+	"char":     "TEXT",
+	"varchar":  "TEXT",
+	"text":     "TEXT",
+	"json":     "TEXT",
+	"decimal":  "REAL",
+	"double":   "REAL",
+	"float":    "REAL",
+	"real":     "REAL",
+}
+
+func sqliteType(typ string) string {
+	if t, ok := sqliteTypes[strings.ToLower(typ)]; ok {
+		return t
+	}
+	return strings.ToUpper(typ)
+}
+
+func writePathPostgres(b *sql.Builder, column string, path []Element, cast string, unquote bool) {
+	if idx := filterIndex(path); idx != -1 {
+		if cast != "" {
+			b.WriteString("CAST(")
+		}
+		b.WriteString("jsonb_path_query_first(")
+		b.Ident(column)
+		b.WriteString("::jsonb, '")
+		b.WriteString(postgresFilterPath(path[:idx], path[idx], path[idx+1:]))
+		b.WriteString("', jsonb_build_object('f', ")
+		b.Arg(postgresFilterVar(path[idx]))
+		b.WriteString("))")
+		if cast != "" {
+			b.WriteString(" AS ")
+			b.WriteString(cast)
+			b.WriteString(")")
+		}
+		return
+	}
+	if cast != "" {
+		b.WriteString("CAST(")
+	}
+	b.Ident(column)
+	for i, e := range path {
+		arrow := "->"
+		if i == len(path)-1 && unquote {
+			arrow = "->>"
+		}
+		b.WriteString(arrow)
+		if e.Kind == KindIndex {
+			b.WriteString(e.Value[1 : len(e.Value)-1])
+		} else {
+			b.WriteString("'" + unquoteSegment(e.Value) + "'")
+		}
+	}
+	if cast != "" {
+		b.WriteString(" AS ")
+		b.WriteString(cast)
+		b.WriteString(")")
+	}
+}
+
+func valuePredicate(column, op string, value interface{}, opts ...PathOption) *sql.Predicate {
+	o := &PathOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return sql.P(func(b *sql.Builder) {
+		writePath(b, column, o)
+		b.WriteString(" " + op + " ")
+		b.Arg(value)
+	})
+}
+
+// ValueEQ returns a predicate for checking that a JSON value (under the given
+// path) equals the given value.
+func ValueEQ(column string, value interface{}, opts ...PathOption) *sql.Predicate {
+	return valuePredicate(column, "=", value, opts...)
+}
+
+// ValueNEQ returns a predicate for checking that a JSON value (under the
+// given path) is not equal to the given value.
+func ValueNEQ(column string, value interface{}, opts ...PathOption) *sql.Predicate {
+	return valuePredicate(column, "<>", value, opts...)
+}
+
+// ValueGT returns a predicate for checking that a JSON value (under the
+// given path) is greater than the given value.
+func ValueGT(column string, value interface{}, opts ...PathOption) *sql.Predicate {
+	return valuePredicate(column, ">", value, opts...)
+}
+
+// ValueGTE returns a predicate for checking that a JSON value (under the
+// given path) is greater than or equal to the given value.
+func ValueGTE(column string, value interface{}, opts ...PathOption) *sql.Predicate {
+	return valuePredicate(column, ">=", value, opts...)
+}
+
+// ValueLT returns a predicate for checking that a JSON value (under the
+// given path) is less than the given value.
+func ValueLT(column string, value interface{}, opts ...PathOption) *sql.Predicate {
+	return valuePredicate(column, "<", value, opts...)
+}
+
+// ValueLTE returns a predicate for checking that a JSON value (under the
+// given path) is less than or equal to the given value.
+func ValueLTE(column string, value interface{}, opts ...PathOption) *sql.Predicate {
+	return valuePredicate(column, "<=", value, opts...)
+}
+
+// ValueContains returns a predicate for checking that a JSON array value
+// under the given path contains value. When the value under path is a
+// scalar (e.g. a string), it matches only if that scalar equals value; no
+// substring match is made.
+//
+// On MySQL it compiles to JSON_CONTAINS(col, CAST(? AS JSON), '$.path'); on
+// Postgres to "col" #> '{path}' @> ?::jsonb; on SQLite to an EXISTS over
+// json_each, since SQLite has no built-in array-containment function.
+func ValueContains(column string, value interface{}, opts ...PathOption) *sql.Predicate {
+	o := &PathOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return sql.P(func(b *sql.Builder) {
+		switch b.Dialect() {
+		case dialect.Postgres:
+			b.Ident(column)
+			b.WriteString(" #> '")
+			b.WriteString(pgPathArray(o.Path))
+			b.WriteString("' @> ")
+			b.Arg(value)
+			b.WriteString("::jsonb")
+		case dialect.SQLite:
+			if sqliteRejectFilter(b, o.Path) {
+				return
+			}
+			b.WriteString("EXISTS (SELECT 1 FROM json_each(")
+			b.Ident(column)
+			b.WriteString(", ")
+			b.WriteString(dotPathSQLite(o.Path))
+			b.WriteString(") WHERE json_each.value = ")
+			b.Arg(value)
+			b.WriteString(")")
+		default:
+			b.WriteString("JSON_CONTAINS(")
+			b.Ident(column)
+			b.WriteString(", CAST(")
+			b.Arg(value)
+			b.WriteString(" AS JSON), ")
+			b.WriteString(dotPath(o.Path))
+			b.WriteString(")")
+		}
+	})
+}
+
+// pgPathArray renders path as a Postgres text[] path literal (e.g.
+// "{friends,0,first}"), the form accepted by the #> and #>> operators.
+func pgPathArray(path []Element) string {
+	parts := make([]string, len(path))
+	for i, e := range path {
+		if e.Kind == KindIndex {
+			parts[i] = e.Value[1 : len(e.Value)-1]
+		} else {
+			parts[i] = unquoteSegment(e.Value)
+		}
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func lenPredicate(column, op string, n int, opts ...PathOption) *sql.Predicate {
+	o := &PathOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return sql.P(func(b *sql.Builder) {
+		switch b.Dialect() {
+		case dialect.Postgres:
+			extract := func() {
+				b.Ident(column)
+				b.WriteString(" #> '")
+				b.WriteString(pgPathArray(o.Path))
+				b.WriteString("'")
+			}
+			b.WriteString("(CASE WHEN jsonb_typeof(")
+			extract()
+			b.WriteString(") = 'array' THEN jsonb_array_length(")
+			extract()
+			b.WriteString(") ELSE NULL END) " + op + " ")
+			b.Arg(n)
+		case dialect.SQLite:
+			if sqliteRejectFilter(b, o.Path) {
+				return
+			}
+			b.WriteString("(CASE WHEN json_type(")
+			b.Ident(column)
+			b.WriteString(", ")
+			b.WriteString(dotPathSQLite(o.Path))
+			b.WriteString(") = 'array' THEN json_array_length(")
+			b.Ident(column)
+			b.WriteString(", ")
+			b.WriteString(dotPathSQLite(o.Path))
+			b.WriteString(") ELSE NULL END) " + op + " ")
+			b.Arg(n)
+		default:
+			b.WriteString("(CASE WHEN JSON_TYPE(JSON_EXTRACT(")
+			b.Ident(column)
+			b.WriteString(", ")
+			b.WriteString(dotPath(o.Path))
+			b.WriteString(")) = 'ARRAY' THEN JSON_LENGTH(")
+			b.Ident(column)
+			b.WriteString(", ")
+			b.WriteString(dotPath(o.Path))
+			b.WriteString(") ELSE NULL END) " + op + " ")
+			b.Arg(n)
+		}
+	})
+}
+
+// LenEQ returns a predicate for checking that the JSON array under the given
+// path has exactly n elements. It evaluates to NULL/false (rather than
+// erroring) when the value at path isn't an array.
+func LenEQ(column string, n int, opts ...PathOption) *sql.Predicate {
+	return lenPredicate(column, "=", n, opts...)
+}
+
+// LenNEQ returns a predicate for checking that the JSON array under the
+// given path does not have exactly n elements.
+func LenNEQ(column string, n int, opts ...PathOption) *sql.Predicate {
+	return lenPredicate(column, "<>", n, opts...)
+}
+
+// LenGT returns a predicate for checking that the JSON array under the
+// given path has more than n elements.
+func LenGT(column string, n int, opts ...PathOption) *sql.Predicate {
+	return lenPredicate(column, ">", n, opts...)
+}
+
+// LenLT returns a predicate for checking that the JSON array under the
+// given path has fewer than n elements.
+func LenLT(column string, n int, opts ...PathOption) *sql.Predicate {
+	return lenPredicate(column, "<", n, opts...)
+}
+
+// HasKey returns a predicate for checking that a JSON value has the given
+// path (i.e. the path resolves to a non-NULL value).
+func HasKey(column string, opts ...PathOption) *sql.Predicate {
+	o := &PathOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return sql.P(func(b *sql.Builder) {
+		// json_extract(...) IS NOT NULL can't tell "key absent" from "key
+		// holds a JSON null" on SQLite; json_type(...) returns NULL only
+		// when the path doesn't resolve to anything at all.
+		if b.Dialect() == dialect.SQLite {
+			if sqliteRejectFilter(b, o.Path) {
+				return
+			}
+			b.WriteString("json_type(")
+			b.Ident(column)
+			b.WriteString(", ")
+			b.WriteString(dotPathSQLite(o.Path))
+			b.WriteString(") IS NOT NULL")
+			return
+		}
+		writePath(b, column, o)
+		b.WriteString(" IS NOT NULL")
+	})
+}
+
+// HasAnyPath returns a predicate for checking that at least one of the given
+// paths exists in the JSON value stored in column.
+//
+// On MySQL it compiles to JSON_CONTAINS_PATH(col, 'one', '$.p1', '$.p2', ...).
+// On Postgres, single-level paths desugar to "col ?| array['p1','p2', ...]";
+// deeper paths fall back to a disjunction of jsonb_path_exists calls.
+func HasAnyPath(column string, paths ...[]string) *sql.Predicate {
+	return hasPaths(column, "one", paths)
+}
+
+// HasAllPath returns a predicate for checking that every one of the given
+// paths exists in the JSON value stored in column.
+//
+// On MySQL it compiles to JSON_CONTAINS_PATH(col, 'all', '$.p1', '$.p2', ...).
+// On Postgres, single-level paths desugar to "col ?& array['p1','p2', ...]";
+// deeper paths fall back to a conjunction of jsonb_path_exists calls.
+func HasAllPath(column string, paths ...[]string) *sql.Predicate {
+	return hasPaths(column, "all", paths)
+}
+
+func hasPaths(column, mode string, paths [][]string) *sql.Predicate {
+	return sql.P(func(b *sql.Builder) {
+		switch b.Dialect() {
+		case dialect.Postgres:
+			writeHasPathsPostgres(b, column, mode, paths)
+		case dialect.SQLite:
+			writeHasPathsSQLite(b, column, mode, paths)
+		default:
+			writeHasPathsMySQL(b, column, mode, paths)
+		}
+	})
+}
+
+// writeHasPathsMySQL emits JSON_CONTAINS_PATH(col, 'one'/'all', '$.p1', ...),
+// which already propagates NULL the way the rest of this package does: a NULL
+// column evaluates the whole expression to NULL.
+func writeHasPathsMySQL(b *sql.Builder, column, mode string, paths [][]string) {
+	b.WriteString("JSON_CONTAINS_PATH(")
+	b.Ident(column)
+	b.WriteString(", '" + mode + "'")
+	for _, p := range paths {
+		b.WriteString(", ")
+		b.WriteString(dotPath(elements(p)))
+	}
+	b.WriteString(")")
+}
+
+// writeHasPathsSQLite emits a conjunction/disjunction of json_type(col,
+// path) IS NOT NULL checks, the same test HasKey uses on this dialect
+// (SQLite has no JSON_CONTAINS_PATH equivalent). Unlike the MySQL form, a
+// NULL column makes each check false rather than NULL.
+func writeHasPathsSQLite(b *sql.Builder, column, mode string, paths [][]string) {
+	sep := " OR "
+	if mode == "all" {
+		sep = " AND "
+	}
+	b.WriteString("(")
+	for i, p := range paths {
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString("json_type(")
+		b.Ident(column)
+		b.WriteString(", ")
+		b.WriteString(dotPathSQLite(elements(p)))
+		b.WriteString(") IS NOT NULL")
+	}
+	b.WriteString(")")
+}
+
+// writeHasPathsPostgres emits the jsonb "?" family of operators for
+// single-level paths (the common case), and falls back to a conjunction
+// or disjunction of jsonb_path_exists calls for nested paths. Both forms
+// already return NULL for a NULL column, the same as MySQL's
+// JSON_CONTAINS_PATH, so no extra NULL-handling is added here.
+func writeHasPathsPostgres(b *sql.Builder, column, mode string, paths [][]string) {
+	flat := true
+	for _, p := range paths {
+		if len(p) != 1 || isIndex(p[0]) {
+			flat = false
+			break
+		}
+	}
+	switch {
+	case flat && mode == "one":
+		b.Ident(column)
+		b.WriteString(" ?| array[")
+		writeStringArray(b, paths)
+		b.WriteString("]")
+	case flat && mode == "all":
+		b.Ident(column)
+		b.WriteString(" ?& array[")
+		writeStringArray(b, paths)
+		b.WriteString("]")
+	default:
+		sep := " OR "
+		if mode == "all" {
+			sep = " AND "
+		}
+		b.WriteString("(")
+		for i, p := range paths {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			b.WriteString("jsonb_path_exists(")
+			b.Ident(column)
+			b.WriteString(", '")
+			b.WriteString(jsonPath(elements(p)))
+			b.WriteString("')")
+		}
+		b.WriteString(")")
+	}
+}
+
+func writeStringArray(b *sql.Builder, paths [][]string) {
+	for i, p := range paths {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("'" + unquoteSegment(p[0]) + "'")
+	}
+}
+
+// jsonPath renders path as a Postgres "$.a.b[1]" JSONPath literal. path must
+// not contain a KindFilter element; see postgresFilterPath for those.
+func jsonPath(path []Element) string {
+	b := &strings.Builder{}
+	b.WriteString("$")
+	for _, e := range path {
+		if e.Kind == KindIndex {
+			b.WriteString(e.Value)
+		} else {
+			b.WriteString(".")
+			b.WriteString(unquoteSegment(e.Value))
+		}
+	}
+	return b.String()
+}
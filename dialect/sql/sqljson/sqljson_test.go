@@ -92,6 +92,174 @@ func TestWritePath(t *testing.T) {
 			wantQuery: `SELECT * FROM "users" WHERE CAST("a"->'b'->'c'->1->'d' AS int) = $1`,
 			wantArgs:  []interface{}{1},
 		},
+		{
+			input: sql.Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.HasAnyPath("a", []string{"b"}, []string{"c"})),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_CONTAINS_PATH(`a`, 'one', \"$.b\", \"$.c\")",
+		},
+		{
+			input: sql.Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.HasAllPath("a", []string{"b"}, []string{"c"})),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_CONTAINS_PATH(`a`, 'all', \"$.b\", \"$.c\")",
+		},
+		{
+			input: sql.Dialect(dialect.Postgres).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.HasAnyPath("a", []string{"b"}, []string{"c"})),
+			wantQuery: `SELECT * FROM "users" WHERE "a" ?| array['b', 'c']`,
+		},
+		{
+			input: sql.Dialect(dialect.Postgres).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.HasAllPath("a", []string{"b", "[1]"})),
+			wantQuery: `SELECT * FROM "users" WHERE (jsonb_path_exists("a", '$.b[1]'))`,
+		},
+		{
+			input: sql.Dialect(dialect.SQLite).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.HasAnyPath("a", []string{"b"}, []string{"c"})),
+			wantQuery: "SELECT * FROM `users` WHERE (json_type(`a`, '$.b') IS NOT NULL OR json_type(`a`, '$.c') IS NOT NULL)",
+		},
+		{
+			input: sql.Dialect(dialect.SQLite).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.HasAllPath("a", []string{"b"}, []string{"c"})),
+			wantQuery: "SELECT * FROM `users` WHERE (json_type(`a`, '$.b') IS NOT NULL AND json_type(`a`, '$.c') IS NOT NULL)",
+		},
+		{
+			input: sql.Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueEQ("a", "Luke", sqljson.DotPath(`friends.#(last=="Murphy").first`))),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_EXTRACT(`a`, REPLACE(JSON_UNQUOTE(JSON_SEARCH(`a`, 'one', ?, NULL, \"$.friends[*].last\")), '.last', '.first')) = ?",
+			wantArgs:  []interface{}{"Murphy", "Luke"},
+		},
+		{
+			// A filter value containing a quote must be bound as an argument,
+			// not spliced into the JSON_SEARCH text.
+			input: sql.Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueEQ("a", "Luke", sqljson.DotPath(`friends.#(last=="x' OR '1'='1").first`))),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_EXTRACT(`a`, REPLACE(JSON_UNQUOTE(JSON_SEARCH(`a`, 'one', ?, NULL, \"$.friends[*].last\")), '.last', '.first')) = ?",
+			wantArgs:  []interface{}{"x' OR '1'='1", "Luke"},
+		},
+		{
+			input: sql.Dialect(dialect.Postgres).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueEQ("a", "Luke", sqljson.DotPath(`friends.#(last=="Murphy").first`))),
+			wantQuery: `SELECT * FROM "users" WHERE jsonb_path_query_first("a"::jsonb, '$.friends[*] ? (@.last == $f).first', jsonb_build_object('f', $1)) = $2`,
+			wantArgs:  []interface{}{"Murphy", "Luke"},
+		},
+		{
+			// Same injection-shaped value on Postgres: it must travel through
+			// jsonb_build_object as a bound argument, never into the path text.
+			input: sql.Dialect(dialect.Postgres).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueEQ("a", "Luke", sqljson.DotPath(`friends.#(last=="'); DROP TABLE users; --").first`))),
+			wantQuery: `SELECT * FROM "users" WHERE jsonb_path_query_first("a"::jsonb, '$.friends[*] ? (@.last == $f).first', jsonb_build_object('f', $1)) = $2`,
+			wantArgs:  []interface{}{"'); DROP TABLE users; --", "Luke"},
+		},
+		{
+			input: sql.Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueEQ("a", "Luke", sqljson.DotPath(`friends.#(age>=30)#.first`))),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_EXTRACT(`a`, REPLACE(JSON_UNQUOTE(JSON_SEARCH(`a`, 'all', ?, NULL, \"$.friends[*].age\")), '.age', '.first')) = ?",
+			wantArgs:  []interface{}{"30", "Luke"},
+		},
+		{
+			input: sql.Dialect(dialect.SQLite).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueEQ("a", 1, sqljson.Path("b", "c", "[1]", "d"), sqljson.Cast("int"))),
+			wantQuery: "SELECT * FROM `users` WHERE CAST(json_extract(`a`, '$.b.c[1].d') AS INTEGER) = ?",
+			wantArgs:  []interface{}{1},
+		},
+		{
+			input: sql.Dialect(dialect.SQLite).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueEQ("a", "a", sqljson.DotPath("b.c[1].d"))),
+			wantQuery: "SELECT * FROM `users` WHERE json_extract(`a`, '$.b.c[1].d') = ?",
+			wantArgs:  []interface{}{"a"},
+		},
+		{
+			input: sql.Dialect(dialect.SQLite).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueEQ("a", "a", sqljson.DotPath("b.\"c[1]\".d[1][2].e"))),
+			wantQuery: "SELECT * FROM `users` WHERE json_extract(`a`, '$.b.\"c[1]\".d[1][2].e') = ?",
+			wantArgs:  []interface{}{"a"},
+		},
+		{
+			input: sql.Dialect(dialect.SQLite).
+				Select("*").
+				From(sql.Table("test")).
+				Where(sqljson.HasKey("j", sqljson.DotPath("a.*.c"))),
+			wantQuery: "SELECT * FROM `test` WHERE json_type(`j`, '$.a.*.c') IS NOT NULL",
+		},
+		{
+			// Unquote is a no-op on SQLite: json_extract already returns an
+			// unquoted scalar, so this renders identically to the case above.
+			input: sql.Dialect(dialect.SQLite).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueEQ("a", "a", sqljson.Path("b", "c", "[1]", "d"), sqljson.Unquote(true))),
+			wantQuery: "SELECT * FROM `users` WHERE json_extract(`a`, '$.b.c[1].d') = ?",
+			wantArgs:  []interface{}{"a"},
+		},
+		{
+			input: sql.Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueContains("tags", "go", sqljson.DotPath("a"))),
+			wantQuery: "SELECT * FROM `users` WHERE JSON_CONTAINS(`tags`, CAST(? AS JSON), \"$.a\")",
+			wantArgs:  []interface{}{"go"},
+		},
+		{
+			input: sql.Dialect(dialect.Postgres).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueContains("tags", "go", sqljson.DotPath("a"))),
+			wantQuery: `SELECT * FROM "users" WHERE "tags" #> '{a}' @> $1::jsonb`,
+			wantArgs:  []interface{}{"go"},
+		},
+		{
+			input: sql.Dialect(dialect.SQLite).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.ValueContains("tags", "go", sqljson.DotPath("a"))),
+			wantQuery: "SELECT * FROM `users` WHERE EXISTS (SELECT 1 FROM json_each(`tags`, '$.a') WHERE json_each.value = ?)",
+			wantArgs:  []interface{}{"go"},
+		},
+		{
+			input: sql.Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.LenEQ("tags", 0, sqljson.DotPath("a"))),
+			wantQuery: "SELECT * FROM `users` WHERE (CASE WHEN JSON_TYPE(JSON_EXTRACT(`tags`, \"$.a\")) = 'ARRAY' THEN JSON_LENGTH(`tags`, \"$.a\") ELSE NULL END) = ?",
+			wantArgs:  []interface{}{0},
+		},
+		{
+			input: sql.Dialect(dialect.Postgres).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.LenGT("tags", 0, sqljson.DotPath("a"))),
+			wantQuery: `SELECT * FROM "users" WHERE (CASE WHEN jsonb_typeof("tags" #> '{a}') = 'array' THEN jsonb_array_length("tags" #> '{a}') ELSE NULL END) > $1`,
+			wantArgs:  []interface{}{0},
+		},
+		{
+			input: sql.Dialect(dialect.SQLite).
+				Select("*").
+				From(sql.Table("users")).
+				Where(sqljson.LenGT("tags", 0, sqljson.DotPath("a"))),
+			wantQuery: "SELECT * FROM `users` WHERE (CASE WHEN json_type(`tags`, '$.a') = 'array' THEN json_array_length(`tags`, '$.a') ELSE NULL END) > ?",
+			wantArgs:  []interface{}{0},
+		},
 	}
 	for i, tt := range tests {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
@@ -102,38 +270,41 @@ func TestWritePath(t *testing.T) {
 	}
 }
 
+func key(v string) sqljson.Element   { return sqljson.Element{Kind: sqljson.KindKey, Value: v} }
+func index(v string) sqljson.Element { return sqljson.Element{Kind: sqljson.KindIndex, Value: v} }
+
 func TestParsePath(t *testing.T) {
 	tests := []struct {
 		input    string
-		wantPath []string
+		wantPath []sqljson.Element
 		wantErr  bool
 	}{
 		{
 			input:    "a.b.c",
-			wantPath: []string{"a", "b", "c"},
+			wantPath: []sqljson.Element{key("a"), key("b"), key("c")},
 		},
 		{
 			input:    "a[1][2]",
-			wantPath: []string{"a", "[1]", "[2]"},
+			wantPath: []sqljson.Element{key("a"), index("[1]"), index("[2]")},
 		},
 		{
 			input:    "a[1][2].b",
-			wantPath: []string{"a", "[1]", "[2]", "b"},
+			wantPath: []sqljson.Element{key("a"), index("[1]"), index("[2]"), key("b")},
 		},
 		{
 			input:    `a."b.c[0]"`,
-			wantPath: []string{"a", `"b.c[0]"`},
+			wantPath: []sqljson.Element{key("a"), key(`"b.c[0]"`)},
 		},
 		{
 			input:    `a."b.c[0]".d`,
-			wantPath: []string{"a", `"b.c[0]"`, "d"},
+			wantPath: []sqljson.Element{key("a"), key(`"b.c[0]"`), key("d")},
 		},
 		{
 			input: `...`,
 		},
 		{
 			input:    `.a.b.`,
-			wantPath: []string{"a", "b"},
+			wantPath: []sqljson.Element{key("a"), key("b")},
 		},
 		{
 			input:   `a."`,
@@ -147,6 +318,37 @@ func TestParsePath(t *testing.T) {
 			input:   `a[a]`,
 			wantErr: true,
 		},
+		{
+			input: `friends.#(last=="Murphy").first`,
+			wantPath: []sqljson.Element{
+				key("friends"),
+				{Kind: sqljson.KindFilter, SubPath: "last", Op: "==", Filter: "Murphy", Quoted: true},
+				key("first"),
+			},
+		},
+		{
+			input: `friends.#(age>=30)#.first`,
+			wantPath: []sqljson.Element{
+				key("friends"),
+				{Kind: sqljson.KindFilter, SubPath: "age", Op: ">=", Filter: "30", All: true},
+				key("first"),
+			},
+		},
+		{
+			input:   `friends.#(last=="Murphy`,
+			wantErr: true,
+		},
+		{
+			input:   `friends.#(last^="Murphy")`,
+			wantErr: true,
+		},
+		{
+			// SubPath is spliced into generated SQL text rather than bound as
+			// an argument (see filterSubPathPattern), so anything outside a
+			// plain dotted identifier must be rejected here.
+			input:   `friends.#(last'=="x").first`,
+			wantErr: true,
+		},
 	}
 	for i, tt := range tests {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {